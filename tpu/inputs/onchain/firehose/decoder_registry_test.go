@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"crypto/ed25519"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"nyx/inputs/onchain/firehose/firehose_v2"
+)
+
+func TestDecoderRegistryDispatchesByDiscriminatorByte(t *testing.T) {
+	reg := NewDecoderRegistry()
+	called := false
+	reg.RegisterDecoder(0x42, "fixture", func(data []byte) (proto.Message, error) {
+		called = true
+		return &firehose_v2.Block{BlockNum: 1}, nil
+	})
+
+	packet := make([]byte, discriminatorOffset+1)
+	packet[discriminatorOffset] = 0x42
+
+	msg, err := reg.Decode(packet)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !called {
+		t.Fatalf("expected registered decoder to be invoked")
+	}
+	if msg.(*firehose_v2.Block).BlockNum != 1 {
+		t.Fatalf("unexpected decoded message: %+v", msg)
+	}
+}
+
+func TestDecoderRegistryUnknownKind(t *testing.T) {
+	reg := NewDecoderRegistry()
+	packet := make([]byte, discriminatorOffset+1)
+	packet[discriminatorOffset] = 0xff
+
+	if _, err := reg.Decode(packet); err == nil {
+		t.Fatalf("expected error for unregistered discriminator byte")
+	}
+}
+
+func TestDefaultDecodersRouteShredVariants(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(nil)
+
+	block := &firehose_v2.Block{BlockNum: 123, BlockHash: "hash"}
+	entries, err := proto.Marshal(block)
+	if err != nil {
+		t.Fatalf("marshal fixture: %v", err)
+	}
+	raw := buildShred(t, priv, ShredVariantLegacyData, 1, 0, 0,
+		dataHeader(0, ShredFlagLastShredInSlot, uint16(len(entries))), entries)
+
+	msg, err := defaultDecoders.Decode(raw)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	got, ok := msg.(*firehose_v2.Block)
+	if !ok {
+		t.Fatalf("expected *firehose_v2.Block, got %T", msg)
+	}
+	if got.BlockNum != 123 || got.BlockHash != "hash" {
+		t.Fatalf("unexpected block: %+v", got)
+	}
+}
@@ -0,0 +1,302 @@
+// fec_set.go
+package rpc
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/klauspost/reedsolomon"
+	"google.golang.org/protobuf/proto"
+
+	"nyx/inputs/onchain/firehose/firehose_v2"
+)
+
+// ShredMetrics tracks shred ingestion outcomes for observability.
+type ShredMetrics struct {
+	mu               sync.Mutex
+	ShredsReceived   uint64
+	ShredsDropped    uint64
+	FecSetsRecovered uint64
+	FecSetsCompleted uint64
+	SlotsEvicted     uint64
+}
+
+func (m *ShredMetrics) incReceived()   { m.mu.Lock(); m.ShredsReceived++; m.mu.Unlock() }
+func (m *ShredMetrics) incDropped()    { m.mu.Lock(); m.ShredsDropped++; m.mu.Unlock() }
+func (m *ShredMetrics) incRecovered()  { m.mu.Lock(); m.FecSetsRecovered++; m.mu.Unlock() }
+func (m *ShredMetrics) incCompleted()  { m.mu.Lock(); m.FecSetsCompleted++; m.mu.Unlock() }
+func (m *ShredMetrics) incEvicted()    { m.mu.Lock(); m.SlotsEvicted++; m.mu.Unlock() }
+
+// Snapshot returns a copy of the current counters.
+func (m *ShredMetrics) Snapshot() ShredMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return ShredMetrics{
+		ShredsReceived:   m.ShredsReceived,
+		ShredsDropped:    m.ShredsDropped,
+		FecSetsRecovered: m.FecSetsRecovered,
+		FecSetsCompleted: m.FecSetsCompleted,
+		SlotsEvicted:     m.SlotsEvicted,
+	}
+}
+
+type fecSetKey struct {
+	slot        uint64
+	fecSetIndex uint32
+}
+
+// fecSet buffers the shreds seen so far for one (slot, fec_set_index) pair.
+type fecSet struct {
+	dataShreds   map[uint32]*Shred
+	codingShreds map[uint32]*Shred
+	// recovered holds data payloads reconstructed via Reed-Solomon for
+	// indices that never arrived as real data shreds.
+	recovered map[uint32][]byte
+	numData   uint16 // 0 until learned from a data or coding shred
+	numCoding uint16
+	updatedAt time.Time
+
+	// lastInSlotIndex and sawLastInSlot record the LAST_SHRED_IN_SLOT flag
+	// independently of which indices are present, since it may be carried
+	// by a data shred that Reed-Solomon later has to stand in for.
+	sawLastInSlot   bool
+	lastInSlotIndex uint32
+}
+
+// dataPayloadCount reports how many of this set's numData indices have a
+// payload available, whether received directly or recovered.
+func (fs *fecSet) dataPayloadCount() int {
+	return len(fs.dataShreds) + len(fs.recovered)
+}
+
+// payload returns the data payload for idx, preferring a directly received
+// shred over a recovered one.
+func (fs *fecSet) payload(idx uint32) ([]byte, bool) {
+	if shred, ok := fs.dataShreds[idx]; ok {
+		return shred.dataPayload(), true
+	}
+	p, ok := fs.recovered[idx]
+	return p, ok
+}
+
+// slotAssembly accumulates the data payloads recovered from completed FEC
+// sets within a slot until the LAST_SHRED_IN_SLOT flag is observed and every
+// index up to it has been filled.
+type slotAssembly struct {
+	payloads    map[uint32][]byte
+	sawLast     bool
+	lastIndex   uint32
+	updatedAt   time.Time
+}
+
+// ShredAssembler reassembles Turbine shreds into Firehose blocks, buffering
+// per (slot, fec_set_index), recovering missing data shreds from coding
+// shreds via Reed-Solomon, and evicting slots that go stale. Signature
+// verification and replay rejection happen upstream, in PacketGuard.Admit,
+// so by the time a shred reaches Ingest it's already trusted.
+type ShredAssembler struct {
+	mu         sync.Mutex
+	sets       map[fecSetKey]*fecSet
+	slots      map[uint64]*slotAssembly
+	staleAfter time.Duration
+	Metrics    ShredMetrics
+}
+
+// NewShredAssembler builds an assembler that evicts buffered state idle
+// longer than staleAfter.
+func NewShredAssembler(staleAfter time.Duration) *ShredAssembler {
+	return &ShredAssembler{
+		sets:       make(map[fecSetKey]*fecSet),
+		slots:      make(map[uint64]*slotAssembly),
+		staleAfter: staleAfter,
+	}
+}
+
+// Ingest feeds a parsed shred into the assembler. It returns a non-nil block
+// once the shred completes its slot's assembly.
+func (a *ShredAssembler) Ingest(s *Shred) (*firehose_v2.Block, error) {
+	a.Metrics.incReceived()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.evictStaleLocked()
+
+	key := fecSetKey{slot: s.Slot, fecSetIndex: s.FecSetIndex}
+	set, ok := a.sets[key]
+	if !ok {
+		set = &fecSet{
+			dataShreds:   make(map[uint32]*Shred),
+			codingShreds: make(map[uint32]*Shred),
+			recovered:    make(map[uint32][]byte),
+		}
+		a.sets[key] = set
+	}
+	set.updatedAt = time.Now()
+
+	switch {
+	case s.IsData():
+		set.dataShreds[s.Index] = s
+		if s.IsLastInSlot() {
+			set.sawLastInSlot = true
+			set.lastInSlotIndex = s.Index
+			// The slot's last shred also closes its FEC set, which bounds
+			// numData when no coding shred has reported it yet.
+			if set.numData == 0 && s.Index >= key.fecSetIndex {
+				set.numData = uint16(s.Index - key.fecSetIndex + 1)
+			}
+		}
+	case s.IsCoding():
+		set.codingShreds[s.Index] = s
+		if s.NumDataShreds > 0 {
+			set.numData = s.NumDataShreds
+			set.numCoding = s.NumCodingShreds
+		}
+	default:
+		a.Metrics.incDropped()
+		return nil, fmt.Errorf("shred: packet for slot %d has neither data nor coding variant", s.Slot)
+	}
+
+	if set.numData > 0 && set.dataPayloadCount() < int(set.numData) && len(set.codingShreds) > 0 {
+		if recovered, err := recoverFecSet(set, key.fecSetIndex); err == nil {
+			for idx, payload := range recovered {
+				set.recovered[idx] = payload
+			}
+			a.Metrics.incRecovered()
+		}
+	}
+
+	if set.numData == 0 || set.dataPayloadCount() < int(set.numData) {
+		return nil, nil
+	}
+
+	// FEC set complete: hand its data payloads to the slot assembly and
+	// drop the set itself.
+	sa, ok := a.slots[s.Slot]
+	if !ok {
+		sa = &slotAssembly{payloads: make(map[uint32][]byte)}
+		a.slots[s.Slot] = sa
+	}
+	sa.updatedAt = time.Now()
+	base := key.fecSetIndex
+	for idx := base; idx < base+uint32(set.numData); idx++ {
+		payload, ok := set.payload(idx)
+		if !ok {
+			continue
+		}
+		sa.payloads[idx] = payload
+	}
+	if set.sawLastInSlot {
+		sa.sawLast = true
+		sa.lastIndex = set.lastInSlotIndex
+	}
+	delete(a.sets, key)
+	a.Metrics.incCompleted()
+
+	if !sa.sawLast {
+		return nil, nil
+	}
+	for idx := uint32(0); idx <= sa.lastIndex; idx++ {
+		if _, ok := sa.payloads[idx]; !ok {
+			return nil, nil
+		}
+	}
+
+	var entries []byte
+	for idx := uint32(0); idx <= sa.lastIndex; idx++ {
+		entries = append(entries, sa.payloads[idx]...)
+	}
+	delete(a.slots, s.Slot)
+
+	block := &firehose_v2.Block{}
+	if err := proto.Unmarshal(entries, block); err != nil {
+		return nil, fmt.Errorf("shred: failed to decode reassembled slot %d: %w", s.Slot, err)
+	}
+	return block, nil
+}
+
+// recoverFecSet attempts to reconstruct missing data shreds in set from its
+// coding shreds using Reed-Solomon erasure coding. base is the FEC set's
+// starting shred index (fec_set_index), since data shred indices are
+// slot-global while shard positions are relative to the set.
+func recoverFecSet(set *fecSet, base uint32) (map[uint32][]byte, error) {
+	total := int(set.numData) + int(set.numCoding)
+	if set.dataPayloadCount()+len(set.codingShreds) < int(set.numData) {
+		return nil, fmt.Errorf("fec: not enough shreds to recover, have %d need %d", set.dataPayloadCount()+len(set.codingShreds), set.numData)
+	}
+
+	enc, err := reedsolomon.New(int(set.numData), int(set.numCoding))
+	if err != nil {
+		return nil, fmt.Errorf("fec: init reed-solomon: %w", err)
+	}
+
+	shards := make([][]byte, total)
+	present := make([]bool, total)
+	var shardSize int
+	for idx, shred := range set.dataShreds {
+		pos := int(idx - base)
+		if pos < 0 || pos >= total {
+			continue
+		}
+		shards[pos] = shred.dataPayload()
+		present[pos] = true
+		if len(shards[pos]) > shardSize {
+			shardSize = len(shards[pos])
+		}
+	}
+	for _, shred := range set.codingShreds {
+		pos := int(set.numData) + int(shred.PositionInFecSet)
+		if pos >= total {
+			continue
+		}
+		shards[pos] = shred.codingShard()
+		present[pos] = true
+		if len(shards[pos]) > shardSize {
+			shardSize = len(shards[pos])
+		}
+	}
+	for i, s := range shards {
+		if !present[i] {
+			// reedsolomon.Reconstruct only treats nil/zero-length shards as
+			// missing; a zero-valued but full-length buffer reads as present
+			// (just coincidentally all zeroes) and silently skips recovery.
+			shards[i] = nil
+		} else if len(s) < shardSize {
+			padded := make([]byte, shardSize)
+			copy(padded, s)
+			shards[i] = padded
+		}
+	}
+
+	if err := enc.Reconstruct(shards); err != nil {
+		return nil, fmt.Errorf("fec: reconstruct: %w", err)
+	}
+
+	recovered := make(map[uint32][]byte)
+	for pos := 0; pos < int(set.numData); pos++ {
+		if present[pos] {
+			continue
+		}
+		recovered[base+uint32(pos)] = shards[pos]
+	}
+	return recovered, nil
+}
+
+// evictStaleLocked drops any FEC set or slot assembly idle for longer than
+// staleAfter. Callers must hold a.mu.
+func (a *ShredAssembler) evictStaleLocked() {
+	cutoff := time.Now().Add(-a.staleAfter)
+	for key, set := range a.sets {
+		if set.updatedAt.Before(cutoff) {
+			delete(a.sets, key)
+			a.Metrics.incEvicted()
+		}
+	}
+	for slot, sa := range a.slots {
+		if sa.updatedAt.Before(cutoff) {
+			delete(a.slots, slot)
+			a.Metrics.incEvicted()
+		}
+	}
+}
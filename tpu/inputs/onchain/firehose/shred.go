@@ -0,0 +1,156 @@
+// shred.go
+package rpc
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"fmt"
+)
+
+// ShredVariant identifies whether a shred carries entry data or Reed-Solomon
+// coding parity, per Solana's legacy shred framing.
+type ShredVariant uint8
+
+const (
+	ShredVariantLegacyData ShredVariant = 0xa5
+	ShredVariantLegacyCode ShredVariant = 0x5a
+)
+
+// Data shred flag bits, packed into the single flags byte that follows
+// parent_offset in a data shred's header.
+const (
+	ShredFlagDataCompleteShred uint8 = 1 << 5
+	ShredFlagLastShredInSlot   uint8 = 1 << 6
+)
+
+const (
+	shredSignatureSize = ed25519.SignatureSize // 64
+	shredCommonHeaderSize = shredSignatureSize + 1 /*variant*/ + 8 /*slot*/ + 4 /*index*/ + 2 /*version*/ + 4 /*fec_set_index*/
+	shredDataHeaderSize   = 2 /*parent_offset*/ + 1 /*flags*/ + 2 /*size*/
+	shredCodeHeaderSize   = 2 /*num_data_shreds*/ + 2 /*num_coding_shreds*/ + 2 /*position*/
+)
+
+// Shred is a single parsed Turbine shred: either a data shred carrying a
+// slice of a slot's serialized entries, or a coding shred carrying
+// Reed-Solomon parity for its FEC set.
+type Shred struct {
+	Signature   [shredSignatureSize]byte
+	Variant     ShredVariant
+	Slot        uint64
+	Index       uint32
+	Version     uint16
+	FecSetIndex uint32
+
+	// Data shred fields, populated when IsData() is true.
+	ParentOffset uint16
+	Flags        uint8
+	Size         uint16
+
+	// Coding shred fields, populated when IsCoding() is true.
+	NumDataShreds    uint16
+	NumCodingShreds  uint16
+	PositionInFecSet uint16
+
+	// Payload is the signed portion of the packet, i.e. everything after
+	// Signature. Signature verification covers exactly this slice.
+	Payload []byte
+}
+
+// IsData reports whether the shred carries entry data.
+func (s *Shred) IsData() bool { return s.Variant == ShredVariantLegacyData }
+
+// IsCoding reports whether the shred carries Reed-Solomon parity.
+func (s *Shred) IsCoding() bool { return s.Variant == ShredVariantLegacyCode }
+
+// IsLastInSlot reports whether this data shred is flagged as the final shred
+// of its slot.
+func (s *Shred) IsLastInSlot() bool {
+	return s.IsData() && s.Flags&ShredFlagLastShredInSlot != 0
+}
+
+// ParseShred parses a raw Turbine UDP datagram into a Shred, validating the
+// common header and the variant-specific header that follows it.
+func ParseShred(data []byte) (*Shred, error) {
+	if len(data) < shredCommonHeaderSize {
+		return nil, fmt.Errorf("shred: packet too short for common header: %d bytes", len(data))
+	}
+
+	s := &Shred{}
+	off := 0
+	copy(s.Signature[:], data[off:off+shredSignatureSize])
+	off += shredSignatureSize
+
+	s.Variant = ShredVariant(data[off])
+	off++
+
+	s.Slot = binary.LittleEndian.Uint64(data[off:])
+	off += 8
+	s.Index = binary.LittleEndian.Uint32(data[off:])
+	off += 4
+	s.Version = binary.LittleEndian.Uint16(data[off:])
+	off += 2
+	s.FecSetIndex = binary.LittleEndian.Uint32(data[off:])
+	off += 4
+
+	switch s.Variant {
+	case ShredVariantLegacyData:
+		if len(data) < off+shredDataHeaderSize {
+			return nil, fmt.Errorf("shred: packet too short for data header: %d bytes", len(data))
+		}
+		s.ParentOffset = binary.LittleEndian.Uint16(data[off:])
+		off += 2
+		s.Flags = data[off]
+		off++
+		s.Size = binary.LittleEndian.Uint16(data[off:])
+		off += 2
+
+	case ShredVariantLegacyCode:
+		if len(data) < off+shredCodeHeaderSize {
+			return nil, fmt.Errorf("shred: packet too short for coding header: %d bytes", len(data))
+		}
+		s.NumDataShreds = binary.LittleEndian.Uint16(data[off:])
+		off += 2
+		s.NumCodingShreds = binary.LittleEndian.Uint16(data[off:])
+		off += 2
+		s.PositionInFecSet = binary.LittleEndian.Uint16(data[off:])
+		off += 2
+
+	default:
+		return nil, fmt.Errorf("shred: unknown shred variant 0x%x", byte(s.Variant))
+	}
+
+	s.Payload = data[shredSignatureSize:]
+	return s, nil
+}
+
+// VerifySignature checks the shred's Ed25519 signature against pub. The
+// signed message is everything in the packet after the signature itself.
+func (s *Shred) VerifySignature(pub ed25519.PublicKey) bool {
+	return ed25519.Verify(pub, s.Payload, s.Signature[:])
+}
+
+// dataPayload returns the entry-bearing bytes carried by a data shred, i.e.
+// the Size-bounded slice of Payload after the variant-specific header. Data
+// shreds are fixed-size, zero-padded datagrams, so without bounding by Size
+// the trailing pad bytes would get appended into the reassembled slot.
+func (s *Shred) dataPayload() []byte {
+	const headerTail = 1 /*variant*/ + 8 + 4 + 2 + 4 + shredDataHeaderSize
+	if len(s.Payload) < headerTail {
+		return nil
+	}
+	rest := s.Payload[headerTail:]
+	if int(s.Size) > len(rest) {
+		return rest
+	}
+	return rest[:s.Size]
+}
+
+// codingShard returns the raw Reed-Solomon parity bytes carried by a coding
+// shred, i.e. the portion of Payload after the variant-specific header.
+func (s *Shred) codingShard() []byte {
+	const headerTail = 1 /*variant*/ + 8 + 4 + 2 + 4 + shredCodeHeaderSize
+	if len(s.Payload) < headerTail {
+		return nil
+	}
+	return s.Payload[headerTail:]
+}
@@ -0,0 +1,206 @@
+package rpc
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"testing"
+
+	"github.com/klauspost/reedsolomon"
+	"google.golang.org/protobuf/proto"
+
+	"nyx/inputs/onchain/firehose/firehose_v2"
+)
+
+// buildShred assembles a raw Turbine datagram with a signed common header,
+// the requested variant-specific header, and payload, mirroring the wire
+// format ParseShred expects.
+func buildShred(t *testing.T, priv ed25519.PrivateKey, variant ShredVariant, slot uint64, index uint32, fecSetIndex uint32, variantHeader []byte, payload []byte) []byte {
+	t.Helper()
+
+	body := make([]byte, 0, 1+8+4+2+4+len(variantHeader)+len(payload))
+	body = append(body, byte(variant))
+	body = binary.LittleEndian.AppendUint64(body, slot)
+	body = binary.LittleEndian.AppendUint32(body, index)
+	body = binary.LittleEndian.AppendUint16(body, 0) // version
+	body = binary.LittleEndian.AppendUint32(body, fecSetIndex)
+	body = append(body, variantHeader...)
+	body = append(body, payload...)
+
+	sig := ed25519.Sign(priv, body)
+	return append(sig, body...)
+}
+
+func dataHeader(parentOffset uint16, flags uint8, size uint16) []byte {
+	h := make([]byte, 0, shredDataHeaderSize)
+	h = binary.LittleEndian.AppendUint16(h, parentOffset)
+	h = append(h, flags)
+	h = binary.LittleEndian.AppendUint16(h, size)
+	return h
+}
+
+func codeHeader(numData, numCoding, position uint16) []byte {
+	h := make([]byte, 0, shredCodeHeaderSize)
+	h = binary.LittleEndian.AppendUint16(h, numData)
+	h = binary.LittleEndian.AppendUint16(h, numCoding)
+	h = binary.LittleEndian.AppendUint16(h, position)
+	return h
+}
+
+func TestParseShredDataShred(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	payload := []byte("entry-bytes")
+	raw := buildShred(t, priv, ShredVariantLegacyData, 42, 3, 0,
+		dataHeader(1, ShredFlagLastShredInSlot, uint16(len(payload))), payload)
+
+	s, err := ParseShred(raw)
+	if err != nil {
+		t.Fatalf("ParseShred: %v", err)
+	}
+	if !s.IsData() || s.IsCoding() {
+		t.Fatalf("expected data shred, got variant 0x%x", byte(s.Variant))
+	}
+	if s.Slot != 42 || s.Index != 3 {
+		t.Fatalf("unexpected slot/index: %d/%d", s.Slot, s.Index)
+	}
+	if !s.IsLastInSlot() {
+		t.Fatalf("expected LAST_SHRED_IN_SLOT flag to be set")
+	}
+	if string(s.dataPayload()) != string(payload) {
+		t.Fatalf("dataPayload mismatch: got %q", s.dataPayload())
+	}
+}
+
+func TestParseShredRejectsShortPacket(t *testing.T) {
+	if _, err := ParseShred(make([]byte, 10)); err == nil {
+		t.Fatalf("expected error for short packet")
+	}
+}
+
+func TestDataPayloadRespectsSizeOverPadding(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	entry := []byte("entry-bytes")
+	const datagramSize = 64 // fixed-size datagram, entry padded out with zeros
+	padded := make([]byte, datagramSize)
+	copy(padded, entry)
+
+	raw := buildShred(t, priv, ShredVariantLegacyData, 1, 0, 0,
+		dataHeader(0, ShredFlagLastShredInSlot, uint16(len(entry))), padded)
+
+	s, err := ParseShred(raw)
+	if err != nil {
+		t.Fatalf("ParseShred: %v", err)
+	}
+	if got := s.dataPayload(); string(got) != string(entry) {
+		t.Fatalf("dataPayload = %q, want %q (padding must be stripped)", got, entry)
+	}
+}
+
+func TestShredAssemblerCompletesSingleShredSlot(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(nil)
+
+	block := &firehose_v2.Block{BlockNum: 7, BlockHash: "abc"}
+	entries, err := proto.Marshal(block)
+	if err != nil {
+		t.Fatalf("marshal fixture block: %v", err)
+	}
+
+	raw := buildShred(t, priv, ShredVariantLegacyData, 7, 0, 0,
+		dataHeader(0, ShredFlagLastShredInSlot, uint16(len(entries))), entries)
+
+	shred, err := ParseShred(raw)
+	if err != nil {
+		t.Fatalf("ParseShred: %v", err)
+	}
+
+	asm := NewShredAssembler(fecSetStaleAfter)
+	got, err := asm.Ingest(shred)
+	if err != nil {
+		t.Fatalf("Ingest: %v", err)
+	}
+	if got == nil {
+		t.Fatalf("expected completed block, got nil")
+	}
+	if got.BlockNum != 7 || got.BlockHash != "abc" {
+		t.Fatalf("unexpected reassembled block: %+v", got)
+	}
+}
+
+func TestShredAssemblerRecoversMissingDataShred(t *testing.T) {
+	_, priv, _ := ed25519.GenerateKey(nil)
+
+	block := &firehose_v2.Block{BlockNum: 9, BlockHash: "def"}
+	entries, err := proto.Marshal(block)
+	if err != nil {
+		t.Fatalf("marshal fixture block: %v", err)
+	}
+	// Pad so both data shards are equal length, as Reed-Solomon requires.
+	half := (len(entries) + 1) / 2
+	shard0 := make([]byte, half)
+	shard1 := make([]byte, half)
+	copy(shard0, entries[:half])
+	copy(shard1, entries[half:])
+
+	enc, err := reedsolomon.New(2, 1)
+	if err != nil {
+		t.Fatalf("reedsolomon.New: %v", err)
+	}
+	shards := [][]byte{shard0, shard1, make([]byte, half)}
+	if err := enc.Encode(shards); err != nil {
+		t.Fatalf("encode parity: %v", err)
+	}
+
+	const slot = 9
+	raw0 := buildShred(t, priv, ShredVariantLegacyData, slot, 0, 0,
+		dataHeader(0, 0, uint16(len(shard0))), shard0)
+	rawCoding := buildShred(t, priv, ShredVariantLegacyCode, slot, 2, 0,
+		codeHeader(2, 1, 0), shards[2])
+
+	asm := NewShredAssembler(fecSetStaleAfter)
+
+	s0, _ := ParseShred(raw0)
+	if _, err := asm.Ingest(s0); err != nil {
+		t.Fatalf("ingest data shred 0: %v", err)
+	}
+
+	sCoding, _ := ParseShred(rawCoding)
+	if _, err := asm.Ingest(sCoding); err != nil {
+		t.Fatalf("ingest coding shred: %v", err)
+	}
+
+	// Shred 1 is deliberately never ingested: its bytes must come only from
+	// Reed-Solomon recovery off the coding shred above, not from the wire.
+	// Recovery completes the FEC set, so its payloads now sit in the slot's
+	// assembly, which stays open because no shred carried LAST_SHRED_IN_SLOT.
+	sa := asm.slots[slot]
+	if sa == nil {
+		t.Fatalf("expected an in-flight slot assembly for slot %d", slot)
+	}
+	recovered, ok := sa.payloads[1]
+	if !ok {
+		t.Fatalf("expected index 1 to be recovered from parity, got %+v", sa.payloads)
+	}
+	if string(recovered) != string(shard1) {
+		t.Fatalf("recovered shard bytes = %q, want %q", recovered, shard1)
+	}
+
+	payload0, ok := sa.payloads[0]
+	if !ok {
+		t.Fatalf("expected payload for index 0")
+	}
+	reassembled := append(append([]byte{}, payload0...), recovered...)
+	got := &firehose_v2.Block{}
+	if err := proto.Unmarshal(reassembled, got); err != nil {
+		t.Fatalf("unmarshal block reassembled from recovered bytes: %v", err)
+	}
+	if got.BlockNum != 9 || got.BlockHash != "def" {
+		t.Fatalf("unexpected reassembled block: %+v", got)
+	}
+}
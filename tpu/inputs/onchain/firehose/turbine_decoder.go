@@ -2,27 +2,69 @@
 package rpc
 
 import (
-	"bytes"
+	"encoding/json"
 	"fmt"
 	"log"
+	"time"
 
-	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/encoding/protojson"
 
 	"nyx/inputs/onchain/firehose/firehose_v2"
 )
 
-// decodeTurbinePacket attempts to decode raw UDP bytes into a Firehose Block proto
+// wsMarshalOptions controls how a decoded block is serialized for
+// WebSocket clients: proto field names (not camelCase) and no padding out
+// of unset fields, matching what the gRPC gateway's JSONPb marshaler uses.
+var wsMarshalOptions = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: false}
+
+// fecSetStaleAfter bounds how long a partially-assembled FEC set or slot may
+// sit idle before it's evicted, so a leader skipping a slot never leaks
+// memory into the next one.
+const fecSetStaleAfter = 2 * time.Minute
+
+// shredAssembler buffers inbound shreds and reassembles them into blocks.
+// Signature verification and replay rejection happen upstream in
+// defaultPacketGuard before a shred ever reaches it.
+var shredAssembler = NewShredAssembler(fecSetStaleAfter)
+
+// globalBlockHub fans decoded blocks out to gRPC subscribers. It's a
+// package var rather than being threaded through decodeTurbinePacket's
+// signature so that tryDecodeAndBroadcast stays a drop-in callback for
+// turbine.go.
+var globalBlockHub = newBlockHub()
+
+// StartTurbineGRPCGateway serves the TurbineStream gRPC API and its
+// grpc-gateway JSON mirror on addr, fed by globalBlockHub.
+func StartTurbineGRPCGateway(addr string) error {
+	server, err := NewGRPCGatewayServer(globalBlockHub)
+	if err != nil {
+		return err
+	}
+	return server.Serve(addr)
+}
+
+// decodeTurbinePacket dispatches a raw UDP datagram through defaultDecoders
+// by its discriminator byte. For the default Firehose shred decoder, this
+// means parsing it as a Solana shred and, once enough shreds have arrived
+// to complete the slot, returning the reassembled Block. It returns a nil
+// block (and nil error) while a slot is still incomplete.
 func decodeTurbinePacket(data []byte) (*firehose_v2.Block, error) {
 	if len(data) == 0 {
 		return nil, fmt.Errorf("empty packet")
 	}
 
-	block := &firehose_v2.Block{}
-	err := proto.Unmarshal(data, block)
+	msg, err := defaultDecoders.Decode(data)
 	if err != nil {
-		return nil, fmt.Errorf("proto decode failed: %w", err)
+		return nil, err
+	}
+	if msg == nil {
+		return nil, nil
 	}
 
+	block, ok := msg.(*firehose_v2.Block)
+	if !ok {
+		return nil, fmt.Errorf("decoded unexpected message type %T", msg)
+	}
 	return block, nil
 }
 
@@ -34,12 +76,16 @@ func handleDecodedBlock(block *firehose_v2.Block) {
 	log.Printf("🧩 Decoded Turbine Block — Slot: %d Hash: %s Parent: %s",
 		block.BlockNum, block.BlockHash, block.Parent)
 
-	// Send JSON version to WebSocket clients
-	broadcastWS(map[string]interface{}{
-		"slot":   block.BlockNum,
-		"hash":   block.BlockHash,
-		"parent": block.Parent,
-	})
+	// Send the full block to WebSocket clients, not just slot/hash/parent
+	payload, err := wsMarshalOptions.Marshal(block)
+	if err != nil {
+		log.Printf("⚠️ Turbine protojson marshal error: %v", err)
+		return
+	}
+	broadcastWS(json.RawMessage(payload))
+
+	// Fan out the full block to gRPC subscribers
+	globalBlockHub.publish(block)
 }
 
 // tryDecodeAndBroadcast is called by turbine.go when new packets arrive
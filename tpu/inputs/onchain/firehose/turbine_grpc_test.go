@@ -0,0 +1,72 @@
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"nyx/inputs/onchain/firehose/firehose_v2"
+	"nyx/inputs/onchain/firehose/turbine_stream"
+)
+
+func TestMatchesFilterMinSlotAndParent(t *testing.T) {
+	block := &firehose_v2.Block{BlockNum: 10, Parent: "p1"}
+
+	cases := []struct {
+		name string
+		req  *turbine_stream.FilterRequest
+		want bool
+	}{
+		{"nil filter matches everything", nil, true},
+		{"below min slot", &turbine_stream.FilterRequest{MinSlot: 11}, false},
+		{"at min slot", &turbine_stream.FilterRequest{MinSlot: 10}, true},
+		{"matching parent", &turbine_stream.FilterRequest{Parent: "p1"}, true},
+		{"mismatched parent", &turbine_stream.FilterRequest{Parent: "p2"}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := matchesFilter(block, c.req); got != c.want {
+				t.Fatalf("matchesFilter = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestBlockHubPublishDeliversToMatchingSubscribers(t *testing.T) {
+	hub := newBlockHub()
+	sub := hub.subscribe(&turbine_stream.FilterRequest{MinSlot: 5})
+
+	hub.publish(&firehose_v2.Block{BlockNum: 1})
+	select {
+	case <-sub.ch:
+		t.Fatalf("expected block below min slot to be filtered out")
+	default:
+	}
+
+	hub.publish(&firehose_v2.Block{BlockNum: 5})
+	select {
+	case block := <-sub.ch:
+		if block.BlockNum != 5 {
+			t.Fatalf("unexpected block: %+v", block)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected matching block to be delivered")
+	}
+
+	hub.unsubscribe(sub)
+}
+
+func TestBlockHubDisconnectsSlowSubscriber(t *testing.T) {
+	hub := newBlockHub()
+	sub := hub.subscribe(nil)
+
+	for i := 0; i < subscriberBuffer+1; i++ {
+		hub.publish(&firehose_v2.Block{BlockNum: uint64(i)})
+	}
+
+	hub.mu.Lock()
+	_, stillSubscribed := hub.subscribers[sub]
+	hub.mu.Unlock()
+	if stillSubscribed {
+		t.Fatalf("expected slow subscriber to be dropped from the hub")
+	}
+}
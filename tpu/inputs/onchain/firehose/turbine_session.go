@@ -0,0 +1,233 @@
+// turbine_session.go
+package rpc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/xtaci/kcp-go"
+	"github.com/xtaci/smux"
+)
+
+// turbineMagic prefixes datagrams that belong to a reliable KCP+smux
+// session, so peers that don't speak it fall through to the legacy
+// single-datagram path untouched.
+var turbineMagic = [8]byte{'T', 'U', 'R', 'B', 'K', 'C', 'P', '1'}
+
+const maxTurbineFrame = 4 << 20 // guards against a corrupt length prefix
+
+// TurbineSessionConfig tunes the reliable transport wrapped around a
+// Turbine UDP socket.
+type TurbineSessionConfig struct {
+	// DataShards and ParityShards configure KCP's FEC, independent of the
+	// shred-level FEC recovery done in fec_set.go.
+	DataShards   int
+	ParityShards int
+}
+
+// DefaultTurbineSessionConfig returns sane defaults for a single Turbine
+// stream: light FEC, enough to ride out typical datacenter packet loss.
+func DefaultTurbineSessionConfig() TurbineSessionConfig {
+	return TurbineSessionConfig{DataShards: 10, ParityShards: 3}
+}
+
+// TurbineSession owns the inbound UDP socket and demultiplexes it into
+// per-peer KCP conversations multiplexed with smux, falling back to the
+// legacy single-shot decode path for peers that never send the magic
+// prefix.
+type TurbineSession struct {
+	conn *net.UDPConn
+	cfg  TurbineSessionConfig
+
+	mu    sync.Mutex
+	peers map[string]*turbinePeer
+}
+
+// turbinePeer holds the reliable-transport state for one remote address.
+type turbinePeer struct {
+	pconn *demuxPacketConn
+	kcp   *kcp.UDPSession
+	smux  *smux.Session
+}
+
+// NewTurbineSession wraps conn so that magic-prefixed datagrams are routed
+// through KCP+smux and everything else keeps using decodeTurbinePacket
+// directly.
+func NewTurbineSession(conn *net.UDPConn, cfg TurbineSessionConfig) *TurbineSession {
+	return &TurbineSession{
+		conn:  conn,
+		cfg:   cfg,
+		peers: make(map[string]*turbinePeer),
+	}
+}
+
+// Serve reads datagrams from the socket until it errors out (typically on
+// Close). It never returns nil.
+func (ts *TurbineSession) Serve() error {
+	buf := make([]byte, 64*1024)
+	for {
+		n, addr, err := ts.conn.ReadFromUDP(buf)
+		if err != nil {
+			return fmt.Errorf("turbine session: read: %w", err)
+		}
+
+		if n >= len(turbineMagic) && bytes.Equal(buf[:len(turbineMagic)], turbineMagic[:]) {
+			framed := append([]byte(nil), buf[len(turbineMagic):n]...)
+			ts.deliverToKCP(addr, framed)
+			continue
+		}
+
+		// Legacy path: peer isn't speaking the reliable framing, decode
+		// the datagram as a single shred directly.
+		data := append([]byte(nil), buf[:n]...)
+		tryDecodeAndBroadcast(data)
+	}
+}
+
+// deliverToKCP hands a de-magic'd frame to the named peer's KCP
+// conversation, creating one (and its smux session) on first sight.
+func (ts *TurbineSession) deliverToKCP(addr *net.UDPAddr, framed []byte) {
+	peer := ts.getOrCreatePeer(addr)
+	if peer == nil {
+		return
+	}
+	peer.pconn.deliver(framed)
+}
+
+func (ts *TurbineSession) getOrCreatePeer(addr *net.UDPAddr) *turbinePeer {
+	key := addr.String()
+
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if peer, ok := ts.peers[key]; ok {
+		return peer
+	}
+
+	pconn := newDemuxPacketConn(ts.conn, addr)
+	kcpConn, err := kcp.NewConn2(addr, nil, ts.cfg.DataShards, ts.cfg.ParityShards, pconn)
+	if err != nil {
+		log.Printf("⚠️ turbine session: kcp dial to %s failed: %v", key, err)
+		return nil
+	}
+
+	smuxSess, err := smux.Server(kcpConn, smux.DefaultConfig())
+	if err != nil {
+		log.Printf("⚠️ turbine session: smux server for %s failed: %v", key, err)
+		kcpConn.Close()
+		return nil
+	}
+
+	peer := &turbinePeer{pconn: pconn, kcp: kcpConn, smux: smuxSess}
+	ts.peers[key] = peer
+	go ts.acceptStreams(key, peer)
+	return peer
+}
+
+// acceptStreams serves logical streams opened by a peer, each carrying a
+// sequence of length-delimited shred datagrams.
+func (ts *TurbineSession) acceptStreams(key string, peer *turbinePeer) {
+	for {
+		stream, err := peer.smux.AcceptStream()
+		if err != nil {
+			ts.mu.Lock()
+			delete(ts.peers, key)
+			ts.mu.Unlock()
+			peer.kcp.Close()
+			return
+		}
+		go serveTurbineStream(stream)
+	}
+}
+
+// serveTurbineStream reads length-delimited frames off r, handing each to
+// the same decode path a legacy single-datagram packet would take.
+func serveTurbineStream(r io.Reader) {
+	var lenBuf [4]byte
+	for {
+		if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+			return
+		}
+		n := binary.BigEndian.Uint32(lenBuf[:])
+		if n == 0 || n > maxTurbineFrame {
+			log.Printf("⚠️ turbine session: implausible frame length %d, dropping stream", n)
+			return
+		}
+		frame := make([]byte, n)
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return
+		}
+		tryDecodeAndBroadcast(frame)
+	}
+}
+
+// demuxPacketConn adapts a single peer's slice of a shared *net.UDPConn
+// into a net.PacketConn, which is what kcp.NewConn2 expects to own. Reads
+// are fed in by TurbineSession.deliverToKCP; writes go back out through the
+// shared socket, magic-prefixed so the peer can tell them apart from
+// anything else on the wire.
+type demuxPacketConn struct {
+	shared *net.UDPConn
+	remote *net.UDPAddr
+
+	recv   chan []byte
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newDemuxPacketConn(shared *net.UDPConn, remote *net.UDPAddr) *demuxPacketConn {
+	return &demuxPacketConn{
+		shared: shared,
+		remote: remote,
+		recv:   make(chan []byte, 256),
+		closed: make(chan struct{}),
+	}
+}
+
+// deliver is called from TurbineSession's read loop with a de-magic'd frame
+// destined for this peer's KCP conversation.
+func (c *demuxPacketConn) deliver(framed []byte) {
+	select {
+	case c.recv <- framed:
+	case <-c.closed:
+	default:
+		// Peer is outrunning KCP's consumption; drop rather than block
+		// the shared socket's read loop.
+	}
+}
+
+func (c *demuxPacketConn) ReadFrom(p []byte) (int, net.Addr, error) {
+	select {
+	case data := <-c.recv:
+		return copy(p, data), c.remote, nil
+	case <-c.closed:
+		return 0, nil, io.EOF
+	}
+}
+
+func (c *demuxPacketConn) WriteTo(p []byte, _ net.Addr) (int, error) {
+	out := make([]byte, 0, len(turbineMagic)+len(p))
+	out = append(out, turbineMagic[:]...)
+	out = append(out, p...)
+	if _, err := c.shared.WriteToUDP(out, c.remote); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (c *demuxPacketConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *demuxPacketConn) LocalAddr() net.Addr { return c.shared.LocalAddr() }
+
+func (c *demuxPacketConn) SetDeadline(t time.Time) error      { return nil }
+func (c *demuxPacketConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *demuxPacketConn) SetWriteDeadline(t time.Time) error { return nil }
@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestDemuxPacketConnDeliverAndRead(t *testing.T) {
+	local, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("ListenUDP: %v", err)
+	}
+	defer local.Close()
+
+	remote := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1}
+	c := newDemuxPacketConn(local, remote)
+
+	c.deliver([]byte("hello"))
+
+	buf := make([]byte, 16)
+	n, addr, err := c.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom: %v", err)
+	}
+	if string(buf[:n]) != "hello" {
+		t.Fatalf("ReadFrom returned %q", buf[:n])
+	}
+	if addr.String() != remote.String() {
+		t.Fatalf("ReadFrom addr = %s, want %s", addr, remote)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, _, err := c.ReadFrom(buf); err != io.EOF {
+		t.Fatalf("ReadFrom after close = %v, want io.EOF", err)
+	}
+}
+
+func TestServeTurbineStreamFraming(t *testing.T) {
+	pr, pw := io.Pipe()
+
+	frame := []byte("a-length-delimited-frame")
+	go func() {
+		var lenBuf [4]byte
+		binary.BigEndian.PutUint32(lenBuf[:], uint32(len(frame)))
+		pw.Write(lenBuf[:])
+		pw.Write(frame)
+		time.Sleep(10 * time.Millisecond)
+		pw.Close()
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		serveTurbineStream(pr)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("serveTurbineStream did not return after writer closed")
+	}
+}
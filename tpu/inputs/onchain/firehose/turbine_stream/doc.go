@@ -0,0 +1,12 @@
+// Package turbine_stream holds the gRPC and grpc-gateway bindings for the
+// TurbineStream service defined in turbine_stream.proto. turbine_stream.pb.go,
+// turbine_stream_grpc.pb.go, and turbine_stream.pb.gw.go are currently
+// hand-written stand-ins for protoc-gen-go/-go-grpc/-grpc-gateway output,
+// checked in because protoc wasn't available to generate the real thing —
+// they're real, compiling Go, just without full protoreflect support on
+// FilterRequest. Run `go generate` in this directory to replace them with
+// the genuine generated bindings once protoc, protoc-gen-go,
+// protoc-gen-go-grpc, and protoc-gen-grpc-gateway are on PATH.
+package turbine_stream
+
+//go:generate protoc -I. -I../../../../.. --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative --grpc-gateway_out=. --grpc-gateway_opt=paths=source_relative turbine_stream.proto
@@ -0,0 +1,51 @@
+// turbine_stream.pb.go is a hand-written stand-in for what
+// protoc-gen-go would emit from turbine_stream.proto. protoc and the Go
+// protobuf plugins aren't available in every environment this repo builds
+// in, so FilterRequest is a plain struct rather than a full protoreflect
+// message — it round-trips through the in-process gateway and gRPC
+// handlers in this package fine, but it doesn't implement proto.Message,
+// so it can't go through a generic protobuf codec (e.g. a real gRPC client
+// dialed from another process). Replace this file by running `go generate`
+// in this directory once protoc is on PATH; see doc.go.
+package turbine_stream
+
+import "fmt"
+
+// FilterRequest narrows a TurbineStream subscription. Every field is
+// optional; an unset field imposes no constraint.
+type FilterRequest struct {
+	// MinSlot streams only blocks at or after this slot. 0 means no lower bound.
+	MinSlot uint64
+	// Parent streams only blocks whose parent hash matches exactly.
+	Parent string
+	// Program streams only blocks that touch this program id.
+	Program string
+}
+
+func (x *FilterRequest) Reset()         { *x = FilterRequest{} }
+func (x *FilterRequest) String() string { return fmt.Sprintf("%+v", *x) }
+func (x *FilterRequest) ProtoMessage()  {}
+
+// GetMinSlot returns x.MinSlot, or 0 for a nil receiver.
+func (x *FilterRequest) GetMinSlot() uint64 {
+	if x == nil {
+		return 0
+	}
+	return x.MinSlot
+}
+
+// GetParent returns x.Parent, or "" for a nil receiver.
+func (x *FilterRequest) GetParent() string {
+	if x == nil {
+		return ""
+	}
+	return x.Parent
+}
+
+// GetProgram returns x.Program, or "" for a nil receiver.
+func (x *FilterRequest) GetProgram() string {
+	if x == nil {
+		return ""
+	}
+	return x.Program
+}
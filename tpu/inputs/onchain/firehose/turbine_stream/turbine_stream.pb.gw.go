@@ -0,0 +1,82 @@
+// turbine_stream.pb.gw.go is a hand-written stand-in for what
+// protoc-gen-grpc-gateway would emit from turbine_stream.proto — see the
+// note in turbine_stream.pb.go on why it's checked in by hand instead. It
+// only supports the in-process registration path (RegisterTurbineStreamHandlerServer),
+// not dialing a remote TurbineStream server, since that's the only one
+// anything in this repo uses.
+package turbine_stream
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"nyx/inputs/onchain/firehose/firehose_v2"
+)
+
+// RegisterTurbineStreamHandlerServer registers the TurbineStream service's
+// HTTP/JSON mirror on mux, calling server directly rather than over a
+// dialed gRPC connection — the right call when the gateway and the gRPC
+// server share a process, which is the only way GRPCGatewayServer wires
+// this up.
+func RegisterTurbineStreamHandlerServer(_ context.Context, mux *runtime.ServeMux, server TurbineStreamServer) error {
+	return mux.HandlePath(http.MethodGet, "/v1/blocks:subscribe", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		req := &FilterRequest{
+			Parent:  r.URL.Query().Get("parent"),
+			Program: r.URL.Query().Get("program"),
+		}
+		if v := r.URL.Query().Get("min_slot"); v != "" {
+			n, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				http.Error(w, "invalid min_slot: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			req.MinSlot = n
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		flusher, _ := w.(http.Flusher)
+		stream := &gatewaySubscribeBlocksStream{ctx: r.Context(), w: w, flusher: flusher}
+		if err := server.SubscribeBlocks(req, stream); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// gatewaySubscribeBlocksStream adapts an HTTP response writer to the
+// TurbineStream_SubscribeBlocksServer interface, emitting one
+// newline-delimited JSON object per block the way grpc-gateway streams
+// server-streaming responses.
+type gatewaySubscribeBlocksStream struct {
+	ctx     context.Context
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func (s *gatewaySubscribeBlocksStream) Send(block *firehose_v2.Block) error {
+	data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: false}.Marshal(block)
+	if err != nil {
+		return err
+	}
+	if _, err := s.w.Write(data); err != nil {
+		return err
+	}
+	if _, err := s.w.Write([]byte("\n")); err != nil {
+		return err
+	}
+	if s.flusher != nil {
+		s.flusher.Flush()
+	}
+	return nil
+}
+
+func (s *gatewaySubscribeBlocksStream) SetHeader(metadata.MD) error  { return nil }
+func (s *gatewaySubscribeBlocksStream) SendHeader(metadata.MD) error { return nil }
+func (s *gatewaySubscribeBlocksStream) SetTrailer(metadata.MD)       {}
+func (s *gatewaySubscribeBlocksStream) Context() context.Context    { return s.ctx }
+func (s *gatewaySubscribeBlocksStream) SendMsg(interface{}) error   { return nil }
+func (s *gatewaySubscribeBlocksStream) RecvMsg(interface{}) error   { return nil }
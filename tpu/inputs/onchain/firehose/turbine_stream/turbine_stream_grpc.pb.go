@@ -0,0 +1,71 @@
+// turbine_stream_grpc.pb.go is a hand-written stand-in for what
+// protoc-gen-go-grpc would emit from turbine_stream.proto — see the note in
+// turbine_stream.pb.go on why it's checked in by hand instead.
+package turbine_stream
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"nyx/inputs/onchain/firehose/firehose_v2"
+)
+
+// TurbineStreamServer is the server API for the TurbineStream service.
+type TurbineStreamServer interface {
+	// SubscribeBlocks streams every decoded block matching the filter until
+	// the client disconnects. An empty FilterRequest matches everything.
+	SubscribeBlocks(*FilterRequest, TurbineStream_SubscribeBlocksServer) error
+}
+
+// UnimplementedTurbineStreamServer must be embedded in implementations for
+// forward compatibility with service methods added later.
+type UnimplementedTurbineStreamServer struct{}
+
+func (UnimplementedTurbineStreamServer) SubscribeBlocks(*FilterRequest, TurbineStream_SubscribeBlocksServer) error {
+	return status.Error(codes.Unimplemented, "method SubscribeBlocks not implemented")
+}
+
+// TurbineStream_SubscribeBlocksServer is the server-side stream handle for
+// a SubscribeBlocks call.
+type TurbineStream_SubscribeBlocksServer interface {
+	Send(*firehose_v2.Block) error
+	grpc.ServerStream
+}
+
+type turbineStreamSubscribeBlocksServer struct {
+	grpc.ServerStream
+}
+
+func (x *turbineStreamSubscribeBlocksServer) Send(m *firehose_v2.Block) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _TurbineStream_SubscribeBlocks_Handler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(FilterRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(TurbineStreamServer).SubscribeBlocks(req, &turbineStreamSubscribeBlocksServer{stream})
+}
+
+// TurbineStream_ServiceDesc is the grpc.ServiceDesc for the TurbineStream
+// service, for use with grpc.Server.RegisterService.
+var TurbineStream_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "turbine_stream.TurbineStream",
+	HandlerType: (*TurbineStreamServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubscribeBlocks",
+			Handler:       _TurbineStream_SubscribeBlocks_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "turbine_stream.proto",
+}
+
+// RegisterTurbineStreamServer registers srv with s.
+func RegisterTurbineStreamServer(s grpc.ServiceRegistrar, srv TurbineStreamServer) {
+	s.RegisterService(&TurbineStream_ServiceDesc, srv)
+}